@@ -0,0 +1,813 @@
+package command
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/cli/cli/context"
+	"github.com/cli/cli/utils"
+	"github.com/google/shlex"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+func init() {
+	RootCmd.AddCommand(aliasCmd)
+	aliasCmd.AddCommand(aliasSetCmd)
+	aliasSetCmd.Flags().BoolP("shell", "s", false, "Declare an alias to be passed through a shell interpreter")
+	aliasSetCmd.Flags().BoolP("interactive", "i", false, "Walk through creating the alias one step at a time")
+	aliasCmd.AddCommand(aliasNewCmd)
+	aliasCmd.AddCommand(aliasListCmd)
+	aliasCmd.AddCommand(aliasDeleteCmd)
+	aliasCmd.AddCommand(aliasImportCmd)
+	aliasImportCmd.Flags().BoolP("clobber", "", false, "Overwrite existing aliases of the same name")
+	aliasCmd.AddCommand(aliasExportCmd)
+	aliasExportCmd.Flags().StringP("format", "", "yaml", "Output format: {yaml|json}")
+}
+
+var aliasCmd = &cobra.Command{
+	Use:   "alias",
+	Short: "Create command shortcuts",
+	Long: `Aliases can be used to make shortcuts for gh commands or to compose multiple commands.
+
+Run "gh help alias set" to learn more.
+`,
+}
+
+var aliasSetCmd = &cobra.Command{
+	Use:   "set <alias> <expansion>",
+	Short: "Create a shortcut for a gh command",
+	Long: `This command lets you write your own shortcuts for running gh. They can be
+simple strings, or accept placeholder arguments.
+
+Use "-" to read the expansion from standard input.
+
+Quotes must always be used around the expansion.
+
+If the expansion begins with "!", gh will treat it as a shell command and
+run it with "sh -c". Prefix the expansion with "!bash:", "!pwsh:", or
+"!cmd:" to run it with bash, PowerShell, or cmd.exe instead, or set the
+"aliases_shell" config key to change the default for all shell aliases.
+`,
+	Example: `
+	$ gh alias set pv 'pr view'
+	$ gh pv -w 123
+	#=> gh pr view -w 123
+
+	$ gh alias set bugs 'issue list --label="bugs"'
+	$ gh bugs
+
+	$ gh alias set epicsby 'issue list --author="$1" --label="epic"'
+	$ gh epicsby vilmibm
+	#=> gh issue list --author="vilmibm" --label="epic"
+
+	$ gh alias set igrep '!gh issue list --label="$1" | grep "$2"'
+	$ gh igrep epic foo
+	#=> gh issue list --label="epic" | grep "foo"
+
+	$ gh alias set --interactive
+	#=> walks through picking a name, a gh command, and any flags
+`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if isInteractive, _ := cmd.Flags().GetBool("interactive"); isInteractive {
+			return cobra.MaximumNArgs(0)(cmd, args)
+		}
+		return cobra.ExactArgs(2)(cmd, args)
+	},
+	RunE: aliasSet,
+}
+
+var aliasNewCmd = &cobra.Command{
+	Use:   "new",
+	Short: "Create an alias by answering a few prompts",
+	Long:  `This command is shorthand for "gh alias set --interactive".`,
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return aliasSetInteractive(cmd)
+	},
+}
+
+var aliasListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List your aliases",
+	Long:  `This command prints out all of the aliases gh is configured to use.`,
+	Args:  cobra.ExactArgs(0),
+	RunE:  aliasList,
+}
+
+var aliasDeleteCmd = &cobra.Command{
+	Use:   "delete <alias>",
+	Short: "Delete an alias",
+	Args:  cobra.ExactArgs(1),
+	RunE:  aliasDelete,
+}
+
+var aliasImportCmd = &cobra.Command{
+	Use:   "import <filename>",
+	Short: "Import aliases from a file",
+	Long: `Read a document of alias definitions and merge them into the current
+config. Use "-" to read from standard input.
+
+Aliases that collide with an existing gh command are always rejected. By
+default, importing an alias name that is already taken is also rejected;
+pass "--clobber" to overwrite it instead.
+`,
+	Args: cobra.ExactArgs(1),
+	RunE: aliasImport,
+}
+
+var aliasExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Print your aliases as a portable file",
+	Long: `This command prints all configured aliases to standard output so they can
+be saved to a file and shared or checked into dotfiles. Pipe the output
+of this command into "gh alias import -" to restore it.
+`,
+	Args: cobra.ExactArgs(0),
+	RunE: aliasExport,
+}
+
+func aliasSet(cmd *cobra.Command, args []string) error {
+	if isInteractive, _ := cmd.Flags().GetBool("interactive"); isInteractive {
+		return aliasSetInteractive(cmd)
+	}
+
+	ctx := contextForCommand(cmd)
+	cfg, err := ctx.Config()
+	if err != nil {
+		return fmt.Errorf("couldn't read config: %w", err)
+	}
+
+	aliasCfg, err := cfg.Aliases()
+	if err != nil {
+		return fmt.Errorf("couldn't read aliases config: %w", err)
+	}
+
+	alias := args[0]
+	expansion := args[1]
+
+	isShell, err := cmd.Flags().GetBool("shell")
+	if err != nil {
+		return fmt.Errorf("could not parse shell flag: %w", err)
+	}
+	if isShell && !strings.HasPrefix(expansion, "!") {
+		expansion = "!" + expansion
+	}
+
+	out := colorableOut(cmd)
+
+	fmt.Fprintf(out, "- Adding alias for %s: %s\n", utils.Bold(alias), utils.Bold(expansion))
+
+	if validCommand(alias) {
+		return fmt.Errorf("could not create alias: %q is already a gh command", alias)
+	}
+
+	if !validAliasExpansion(expansion) {
+		return fmt.Errorf("could not create alias: %s does not correspond to a gh command", expansion)
+	}
+
+	if err := validAliasArityAndShell(expansion); err != nil {
+		return fmt.Errorf("could not create alias: %s", err)
+	}
+
+	oldExpansion, ok := aliasCfg.Get(alias)
+	if ok {
+		fmt.Fprintf(out, "%s Changed alias %s from %s to %s\n", utils.Yellow("!"), utils.Bold(alias), utils.Bold(oldExpansion), utils.Bold(expansion))
+	}
+
+	err = aliasCfg.Add(alias, expansion)
+	if err != nil {
+		return fmt.Errorf("could not create alias: %s", err)
+	}
+
+	fmt.Fprintf(out, "%s Added alias.\n", utils.GreenCheck())
+
+	return nil
+}
+
+func aliasList(cmd *cobra.Command, args []string) error {
+	ctx := contextForCommand(cmd)
+	cfg, err := ctx.Config()
+	if err != nil {
+		return fmt.Errorf("couldn't read config: %w", err)
+	}
+
+	aliasCfg, err := cfg.Aliases()
+	if err != nil {
+		return fmt.Errorf("couldn't read aliases config: %w", err)
+	}
+
+	aliases := aliasCfg.All()
+	if len(aliases) == 0 {
+		return nil
+	}
+
+	out := colorableOut(cmd)
+
+	names := []string{}
+	for name := range aliases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		fmt.Fprintf(out, "%s\t%s\n", name, aliases[name])
+	}
+
+	return nil
+}
+
+func aliasDelete(cmd *cobra.Command, args []string) error {
+	ctx := contextForCommand(cmd)
+	cfg, err := ctx.Config()
+	if err != nil {
+		return fmt.Errorf("couldn't read config: %w", err)
+	}
+
+	aliasCfg, err := cfg.Aliases()
+	if err != nil {
+		return fmt.Errorf("couldn't read aliases config: %w", err)
+	}
+
+	alias := args[0]
+	expansion, ok := aliasCfg.Get(alias)
+	if !ok {
+		return fmt.Errorf("no such alias %s", alias)
+	}
+
+	err = aliasCfg.Delete(alias)
+	if err != nil {
+		return fmt.Errorf("failed to delete alias %s: %w", alias, err)
+	}
+
+	out := colorableOut(cmd)
+	fmt.Fprintf(out, "%s Deleted alias %s; was %s\n", utils.GreenCheck(), alias, expansion)
+
+	return nil
+}
+
+func aliasImport(cmd *cobra.Command, args []string) error {
+	ctx := contextForCommand(cmd)
+	cfg, err := ctx.Config()
+	if err != nil {
+		return fmt.Errorf("couldn't read config: %w", err)
+	}
+
+	aliasCfg, err := cfg.Aliases()
+	if err != nil {
+		return fmt.Errorf("couldn't read aliases config: %w", err)
+	}
+
+	clobber, err := cmd.Flags().GetBool("clobber")
+	if err != nil {
+		return fmt.Errorf("could not parse clobber flag: %w", err)
+	}
+
+	filename := args[0]
+	var data []byte
+	if filename == "-" {
+		data, err = ioutil.ReadAll(cmd.InOrStdin())
+	} else {
+		data, err = ioutil.ReadFile(filename)
+	}
+	if err != nil {
+		return fmt.Errorf("could not read %s: %w", filename, err)
+	}
+
+	var incoming map[string]string
+	if err := yaml.Unmarshal(data, &incoming); err != nil {
+		return fmt.Errorf("could not parse aliases: %w", err)
+	}
+
+	names := []string{}
+	for name := range incoming {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	out := colorableOut(cmd)
+	var added, skipped int
+
+	for _, alias := range names {
+		expansion := incoming[alias]
+
+		if validCommand(alias) {
+			fmt.Fprintf(out, "%s skipping alias %s: already a gh command\n", utils.Yellow("!"), utils.Bold(alias))
+			skipped++
+			continue
+		}
+
+		if !validAliasExpansion(expansion) {
+			fmt.Fprintf(out, "%s skipping alias %s: %s does not correspond to a gh command\n", utils.Yellow("!"), utils.Bold(alias), expansion)
+			skipped++
+			continue
+		}
+
+		if err := validAliasArityAndShell(expansion); err != nil {
+			fmt.Fprintf(out, "%s skipping alias %s: %s\n", utils.Yellow("!"), utils.Bold(alias), err)
+			skipped++
+			continue
+		}
+
+		if _, ok := aliasCfg.Get(alias); ok && !clobber {
+			fmt.Fprintf(out, "%s skipping alias %s: already exists, use --clobber to overwrite\n", utils.Yellow("!"), utils.Bold(alias))
+			skipped++
+			continue
+		}
+
+		if err := aliasCfg.Add(alias, expansion); err != nil {
+			return fmt.Errorf("could not import alias %s: %w", alias, err)
+		}
+		fmt.Fprintf(out, "%s Imported alias %s: %s\n", utils.GreenCheck(), utils.Bold(alias), utils.Bold(expansion))
+		added++
+	}
+
+	fmt.Fprintf(out, "Imported %d alias(es), skipped %d\n", added, skipped)
+
+	return nil
+}
+
+func aliasExport(cmd *cobra.Command, args []string) error {
+	ctx := contextForCommand(cmd)
+	cfg, err := ctx.Config()
+	if err != nil {
+		return fmt.Errorf("couldn't read config: %w", err)
+	}
+
+	aliasCfg, err := cfg.Aliases()
+	if err != nil {
+		return fmt.Errorf("couldn't read aliases config: %w", err)
+	}
+
+	format, err := cmd.Flags().GetString("format")
+	if err != nil {
+		return fmt.Errorf("could not parse format flag: %w", err)
+	}
+
+	aliases := aliasCfg.All()
+
+	var data []byte
+	switch format {
+	case "yaml", "":
+		data, err = yaml.Marshal(aliases)
+	case "json":
+		data, err = json.MarshalIndent(aliases, "", "  ")
+		data = append(data, '\n')
+	default:
+		return fmt.Errorf("unsupported format: %s", format)
+	}
+	if err != nil {
+		return fmt.Errorf("could not serialize aliases: %w", err)
+	}
+
+	out := cmd.OutOrStdout()
+	_, err = io.Copy(out, strings.NewReader(string(data)))
+	return err
+}
+
+// aliasSetInteractive walks the user through choosing an alias name, a
+// target gh command, and any extra flags or placeholders, previews the
+// resulting expansion against sample arguments when it uses positional
+// placeholders, and saves it once confirmed.
+func aliasSetInteractive(cmd *cobra.Command) error {
+	if !isTerminal(os.Stdin) {
+		return fmt.Errorf("interactive alias creation requires a terminal")
+	}
+
+	ctx := contextForCommand(cmd)
+	cfg, err := ctx.Config()
+	if err != nil {
+		return fmt.Errorf("couldn't read config: %w", err)
+	}
+
+	aliasCfg, err := cfg.Aliases()
+	if err != nil {
+		return fmt.Errorf("couldn't read aliases config: %w", err)
+	}
+
+	out := colorableOut(cmd)
+
+	var alias string
+	err = survey.AskOne(&survey.Input{
+		Message: "What should the alias be called?",
+	}, &alias, survey.WithValidator(func(val interface{}) error {
+		name, _ := val.(string)
+		if name == "" {
+			return fmt.Errorf("an alias name is required")
+		}
+		if validCommand(name) {
+			return fmt.Errorf("%q is already a gh command", name)
+		}
+		if _, ok := aliasCfg.Get(name); ok {
+			return fmt.Errorf("alias %q already exists", name)
+		}
+		return nil
+	}))
+	if err != nil {
+		return err
+	}
+
+	var target string
+	err = survey.AskOne(&survey.Select{
+		Message: "Which command should it run?",
+		Options: ghCommandPaths(),
+	}, &target, survey.WithValidator(survey.Required))
+	if err != nil {
+		return err
+	}
+
+	var extra string
+	err = survey.AskOne(&survey.Input{
+		Message: `Any extra flags or placeholders? (e.g. --label="$1")`,
+	}, &extra)
+	if err != nil {
+		return err
+	}
+
+	expansion := target
+	if extra != "" {
+		expansion = fmt.Sprintf("%s %s", target, extra)
+	}
+
+	arity, err := aliasArity(expansion)
+	if err != nil {
+		return fmt.Errorf("could not create alias: %s", err)
+	}
+
+	if arity > 0 {
+		var sample string
+		err = survey.AskOne(&survey.Input{
+			Message: fmt.Sprintf("Enter %d sample argument(s) to preview the expansion:", arity),
+		}, &sample)
+		if err != nil {
+			return err
+		}
+
+		preview, previewErr := expandPlaceholders(expansion, strings.Fields(sample), map[string]string{})
+		if previewErr != nil {
+			return fmt.Errorf("could not create alias: %s", previewErr)
+		}
+		fmt.Fprintf(out, "- %s will expand to: %s\n", utils.Bold(alias), utils.Bold(strings.Join(preview, " ")))
+	}
+
+	confirmed := false
+	err = survey.AskOne(&survey.Confirm{
+		Message: fmt.Sprintf("Save alias %q?", alias),
+		Default: true,
+	}, &confirmed)
+	if err != nil {
+		return err
+	}
+	if !confirmed {
+		return nil
+	}
+
+	if err := aliasCfg.Add(alias, expansion); err != nil {
+		return fmt.Errorf("could not create alias: %s", err)
+	}
+
+	fmt.Fprintf(out, "%s Added alias.\n", utils.GreenCheck())
+
+	return nil
+}
+
+// ghCommandPaths returns the invocable command paths of the cobra command
+// tree (e.g. "issue list", "pr checkout"), for offering as choices in the
+// interactive alias wizard.
+func ghCommandPaths() []string {
+	var paths []string
+
+	var walk func(cmd *cobra.Command)
+	walk = func(cmd *cobra.Command) {
+		for _, c := range cmd.Commands() {
+			if c.Hidden || c == aliasCmd {
+				continue
+			}
+			if c.Runnable() {
+				paths = append(paths, strings.TrimPrefix(c.CommandPath(), RootCmd.Name()+" "))
+			}
+			walk(c)
+		}
+	}
+	walk(RootCmd)
+
+	sort.Strings(paths)
+	return paths
+}
+
+func validCommand(expansion string) bool {
+	split, err := splitAliasTokens(expansion)
+	if err != nil || len(split) == 0 {
+		return false
+	}
+
+	cmd, _, err := RootCmd.Find(split)
+	return err == nil && cmd != RootCmd
+}
+
+func validAliasExpansion(expansion string) bool {
+	if strings.HasPrefix(expansion, "!") {
+		return true
+	}
+	return validCommand(expansion)
+}
+
+// validAliasArityAndShell rejects expansions that validAliasExpansion lets
+// through but that would fail at expand time: placeholder-numbering gaps in
+// a non-shell expansion, and an unrecognized "!shell: ..." prefix.
+func validAliasArityAndShell(expansion string) error {
+	if !strings.HasPrefix(expansion, "!") {
+		if _, err := aliasArity(expansion); err != nil {
+			return err
+		}
+		return nil
+	}
+
+	if m := anyShellPrefixRE.FindStringSubmatch(expansion[1:]); m != nil {
+		if _, ok := shellRunners[m[1]]; !ok {
+			return fmt.Errorf("unrecognized alias shell %q", m[1])
+		}
+	}
+
+	return nil
+}
+
+func findExecutable(names ...string) (string, error) {
+	var lastErr error
+	for _, name := range names {
+		path, err := exec.LookPath(name)
+		if err == nil {
+			return path, nil
+		}
+		lastErr = err
+	}
+	return "", fmt.Errorf("unable to locate %s: %w", strings.Join(names, " or "), lastErr)
+}
+
+var findSh = func() (string, error) {
+	return findExecutable("sh")
+}
+
+var findBash = func() (string, error) {
+	return findExecutable("bash")
+}
+
+var findPwsh = func() (string, error) {
+	return findExecutable("pwsh", "powershell")
+}
+
+var findCmd = func() (string, error) {
+	return findExecutable("cmd.exe", "cmd")
+}
+
+// ShellRunner knows how to locate its shell binary and build the argv
+// needed to execute a script through it.
+type ShellRunner interface {
+	Command(script string) ([]string, error)
+}
+
+type shellRunner struct {
+	find func() (string, error)
+	flag string
+}
+
+func (r shellRunner) Command(script string) ([]string, error) {
+	path, err := r.find()
+	if err != nil {
+		return nil, err
+	}
+	return []string{path, r.flag, script}, nil
+}
+
+// defaultShellRunnerName is used for plain "!"-prefixed aliases that don't
+// specify a runner prefix and aren't overridden by the "aliases_shell"
+// config key.
+const defaultShellRunnerName = "sh"
+
+// anyShellPrefixRE matches the shape of a per-alias runner override, e.g.
+// the "pwsh:" in "!pwsh: gh issue list | Select-String cool", regardless of
+// whether the name is one gh actually knows about, so that a typo like
+// "!zsh: ..." can be rejected instead of silently treated as script text
+// for the default shell.
+var anyShellPrefixRE = regexp.MustCompile(`^([a-zA-Z][\w-]*):`)
+
+// The find funcs below are indirected through the package-level findSh,
+// findBash, etc. vars (rather than captured directly) so that tests can
+// swap those vars out after shellRunners has already been built.
+var shellRunners = map[string]ShellRunner{
+	"sh":   shellRunner{find: func() (string, error) { return findSh() }, flag: "-c"},
+	"bash": shellRunner{find: func() (string, error) { return findBash() }, flag: "-c"},
+	"pwsh": shellRunner{find: func() (string, error) { return findPwsh() }, flag: "-Command"},
+	"cmd":  shellRunner{find: func() (string, error) { return findCmd() }, flag: "/C"},
+}
+
+// splitAliasTokens tokenizes an alias expansion the same way a POSIX shell
+// would split a command line. Aliases are stored as the expansion string the
+// user wrote, not as tokens, so the string is tokenized again each time an
+// alias is validated or expanded.
+func splitAliasTokens(in string) ([]string, error) {
+	tokens, err := shlex.Split(in)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse alias expansion: %w", err)
+	}
+	return tokens, nil
+}
+
+// ExpandAlias processes args to see if they match an alias command and
+// expands it if so.
+func ExpandAlias(args []string) (expanded []string, isShell bool, err error) {
+	if len(args) < 2 {
+		expanded = []string{}
+		return
+	}
+
+	ctx := context.New()
+	cfg, err := ctx.Config()
+	if err != nil {
+		return
+	}
+	aliases, err := cfg.Aliases()
+	if err != nil {
+		return
+	}
+
+	expansion, ok := aliases.Get(args[1])
+	if !ok {
+		expanded = args[1:]
+		return
+	}
+
+	if strings.HasPrefix(expansion, "!") {
+		isShell = true
+
+		script := expansion[1:]
+		runnerName := defaultShellRunnerName
+		if configured, getErr := cfg.Get("", "aliases_shell"); getErr == nil && configured != "" {
+			runnerName = configured
+		}
+		if m := anyShellPrefixRE.FindStringSubmatch(script); m != nil {
+			if _, ok := shellRunners[m[1]]; !ok {
+				err = fmt.Errorf("unrecognized alias shell %q", m[1])
+				return
+			}
+			runnerName = m[1]
+			script = strings.TrimPrefix(script[len(m[0]):], " ")
+		}
+
+		runner, ok := shellRunners[runnerName]
+		if !ok {
+			err = fmt.Errorf("unrecognized alias shell %q", runnerName)
+			return
+		}
+
+		expanded, err = runner.Command(script)
+		if err != nil {
+			return
+		}
+
+		if len(args[2:]) > 0 {
+			expanded = append(expanded, "--")
+			expanded = append(expanded, args[2:]...)
+		}
+
+		return
+	}
+
+	extraArgs, namedArgs, err := extractAliasArgs(args[2:])
+	if err != nil {
+		return
+	}
+
+	expanded, err = expandPlaceholders(expansion, extraArgs, namedArgs)
+	return
+}
+
+// expandPlaceholders tokenizes an alias expansion and then substitutes the
+// positional ($1, $2, ...), splat ($@), and named (${name}) placeholders in
+// the resulting tokens, ready to be executed. It is shared by ExpandAlias and the
+// "alias set --interactive" preview step.
+func expandPlaceholders(expansion string, extraArgs []string, namedArgs map[string]string) ([]string, error) {
+	arity, err := aliasArity(expansion)
+	if err != nil {
+		return nil, err
+	}
+	if len(extraArgs) < arity {
+		return nil, fmt.Errorf("not enough arguments for alias: %s", expansion)
+	}
+
+	for _, name := range namedPlaceholders(expansion) {
+		if _, ok := namedArgs[name]; !ok {
+			return nil, fmt.Errorf("alias requires a value for ${%s}; pass --arg %s=VALUE", name, name)
+		}
+	}
+
+	tokens, err := splitAliasTokens(expansion)
+	if err != nil {
+		return nil, err
+	}
+
+	positional, trailing := extraArgs[:arity], extraArgs[arity:]
+
+	result := make([]string, 0, len(tokens)+len(trailing))
+	for _, tok := range tokens {
+		if tok == "$@" {
+			result = append(result, trailing...)
+			trailing = nil
+			continue
+		}
+
+		tok = positionalPlaceholderRE.ReplaceAllStringFunc(tok, func(m string) string {
+			n, _ := strconv.Atoi(m[1:])
+			return positional[n-1]
+		})
+		tok = namedPlaceholderRE.ReplaceAllStringFunc(tok, func(m string) string {
+			return namedArgs[m[2:len(m)-1]]
+		})
+		result = append(result, tok)
+	}
+
+	return append(result, trailing...), nil
+}
+
+var positionalPlaceholderRE = regexp.MustCompile(`\$(\d+)`)
+var namedPlaceholderRE = regexp.MustCompile(`\$\{(\w+)\}`)
+
+// aliasArity reports the number of positional ($1, $2, ...) arguments an
+// alias expansion requires, or an error if the placeholders it references
+// skip a number (e.g. "$1 $3" with no "$2").
+func aliasArity(expansion string) (int, error) {
+	seen := map[int]bool{}
+	max := 0
+	for _, m := range positionalPlaceholderRE.FindAllStringSubmatch(expansion, -1) {
+		n, _ := strconv.Atoi(m[1])
+		if n < 1 {
+			return 0, fmt.Errorf("alias expansion references invalid placeholder $%d: %s", n, expansion)
+		}
+		seen[n] = true
+		if n > max {
+			max = n
+		}
+	}
+	for n := 1; n <= max; n++ {
+		if !seen[n] {
+			return 0, fmt.Errorf("alias expansion skips $%d: %s", n, expansion)
+		}
+	}
+	return max, nil
+}
+
+// namedPlaceholders returns the distinct ${name} placeholders referenced by
+// an alias expansion, in the order they first appear.
+func namedPlaceholders(expansion string) []string {
+	var names []string
+	seen := map[string]bool{}
+	for _, m := range namedPlaceholderRE.FindAllStringSubmatch(expansion, -1) {
+		if !seen[m[1]] {
+			seen[m[1]] = true
+			names = append(names, m[1])
+		}
+	}
+	return names
+}
+
+// extractAliasArgs splits the arguments an alias was invoked with into the
+// positional/trailing arguments that feed $1, $2, ... and $@, and the named
+// arguments supplied via repeated "--arg name=value" flags that feed
+// ${name} placeholders.
+func extractAliasArgs(args []string) (remaining []string, named map[string]string, err error) {
+	named = map[string]string{}
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+
+		var kv string
+		switch {
+		case arg == "--arg":
+			if i+1 >= len(args) {
+				return nil, nil, fmt.Errorf("--arg requires a name=value argument")
+			}
+			i++
+			kv = args[i]
+		case strings.HasPrefix(arg, "--arg="):
+			kv = strings.TrimPrefix(arg, "--arg=")
+		default:
+			remaining = append(remaining, arg)
+			continue
+		}
+
+		parts := strings.SplitN(kv, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, nil, fmt.Errorf("invalid --arg %q: expected name=value", kv)
+		}
+		named[parts[0]] = parts[1]
+	}
+	return
+}
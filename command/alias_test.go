@@ -2,6 +2,8 @@ package command
 
 import (
 	"bytes"
+	"io/ioutil"
+	"os"
 	"strings"
 	"testing"
 
@@ -20,6 +22,36 @@ func stubSh(value string) func() {
 	}
 }
 
+func stubBash(value string) func() {
+	orig := findBash
+	findBash = func() (string, error) {
+		return value, nil
+	}
+	return func() {
+		findBash = orig
+	}
+}
+
+func stubPwsh(value string) func() {
+	orig := findPwsh
+	findPwsh = func() (string, error) {
+		return value, nil
+	}
+	return func() {
+		findPwsh = orig
+	}
+}
+
+func stubCmd(value string) func() {
+	orig := findCmd
+	findCmd = func() (string, error) {
+		return value, nil
+	}
+	return func() {
+		findCmd = orig
+	}
+}
+
 func TestAliasSet_gh_command(t *testing.T) {
 	initBlankContext("", "OWNER/REPO", "trunk")
 
@@ -236,6 +268,27 @@ aliases:
 	assert.Equal(t, expected, expanded)
 }
 
+func TestExpandAlias_shell_passes_through_arg_flag(t *testing.T) {
+	defer stubSh("sh")()
+	cfg := `---
+aliases:
+  ig: '!gh issue list | grep cool'
+`
+	initBlankContext(cfg, "OWNER/REPO", "trunk")
+
+	expanded, isShell, err := ExpandAlias([]string{"gh", "ig", "--arg", "x=y"})
+
+	assert.True(t, isShell)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	expected := []string{"sh", "-c", "gh issue list | grep cool", "--", "--arg", "x=y"}
+
+	assert.Equal(t, expected, expanded)
+}
+
 func TestExpandAlias(t *testing.T) {
 	cfg := `---
 aliases:
@@ -251,7 +304,7 @@ aliases:
 	}{
 		{"gh co", []string{"pr", "checkout"}, ""},
 		{"gh il", nil, `not enough arguments for alias: issue list --author="$1" --label="$2"`},
-		{"gh il vilmibm", nil, `not enough arguments for alias: issue list --author="vilmibm" --label="$2"`},
+		{"gh il vilmibm", nil, `not enough arguments for alias: issue list --author="$1" --label="$2"`},
 		{"gh co 123", []string{"pr", "checkout", "123"}, ""},
 		{"gh il vilmibm epic", []string{"issue", "list", `--author=vilmibm`, `--label=epic`}, ""},
 		{"gh ia vilmibm", []string{"issue", "list", `--author=vilmibm`, `--assignee=vilmibm`}, ""},
@@ -421,3 +474,301 @@ func TestShellAlias_bang(t *testing.T) {
 
 	eq(t, mainBuf.String(), expected)
 }
+
+func TestAliasExport_yaml(t *testing.T) {
+	cfg := `---
+aliases:
+  co: pr checkout
+  igrep: '!gh issue list | grep'
+`
+	initBlankContext(cfg, "OWNER/REPO", "trunk")
+
+	output, err := RunCommand("alias export")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	expected := "co: pr checkout\nigrep: '!gh issue list | grep'\n"
+	eq(t, output.String(), expected)
+}
+
+func TestAliasExport_json(t *testing.T) {
+	cfg := `---
+aliases:
+  co: pr checkout
+`
+	initBlankContext(cfg, "OWNER/REPO", "trunk")
+
+	output, err := RunCommand("alias export --format json")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	expected := "{\n  \"co\": \"pr checkout\"\n}\n"
+	eq(t, output.String(), expected)
+}
+
+func TestAliasImport(t *testing.T) {
+	initBlankContext("", "OWNER/REPO", "trunk")
+	defer stubTerminal(true)()
+
+	mainBuf := bytes.Buffer{}
+	hostsBuf := bytes.Buffer{}
+	defer config.StubWriteConfig(&mainBuf, &hostsBuf)()
+
+	f, err := ioutil.TempFile("", "gh-alias-import-*.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	_, _ = f.WriteString("co: pr checkout\nprs: pr status\n")
+	f.Close()
+
+	output, err := RunCommand("alias import " + f.Name())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	test.ExpectLines(t, output.Stderr(), "Imported alias.*co.*pr checkout", "Imported alias.*prs.*pr status")
+
+	expected := `aliases:
+    co: pr checkout
+    prs: pr status
+`
+	eq(t, mainBuf.String(), expected)
+}
+
+func TestAliasImport_clobber(t *testing.T) {
+	cfg := `---
+aliases:
+  co: pr checkout -Rcool/repo
+`
+	initBlankContext(cfg, "OWNER/REPO", "trunk")
+	defer stubTerminal(true)()
+
+	mainBuf := bytes.Buffer{}
+	hostsBuf := bytes.Buffer{}
+	defer config.StubWriteConfig(&mainBuf, &hostsBuf)()
+
+	f, err := ioutil.TempFile("", "gh-alias-import-*.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	_, _ = f.WriteString("co: pr checkout\n")
+	f.Close()
+
+	output, err := RunCommand("alias import " + f.Name())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	test.ExpectLines(t, output.Stderr(), "skipping alias.*co.*already exists")
+
+	output, err = RunCommand("alias import --clobber " + f.Name())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	test.ExpectLines(t, output.Stderr(), "Imported alias.*co.*pr checkout")
+
+	expected := `aliases:
+    co: pr checkout
+`
+	eq(t, mainBuf.String(), expected)
+}
+
+func TestAliasImport_rejects_invalid_expansions(t *testing.T) {
+	initBlankContext("", "OWNER/REPO", "trunk")
+	defer stubTerminal(true)()
+
+	mainBuf := bytes.Buffer{}
+	hostsBuf := bytes.Buffer{}
+	defer config.StubWriteConfig(&mainBuf, &hostsBuf)()
+
+	f, err := ioutil.TempFile("", "gh-alias-import-*.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	_, _ = f.WriteString("co: pr checkout\ngap: 'issue list --author=\"$1\" --label=\"$3\"'\nbadshell: '!zsh: gh issue list'\n")
+	f.Close()
+
+	output, err := RunCommand("alias import " + f.Name())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	test.ExpectLines(t, output.Stderr(),
+		"Imported alias.*co.*pr checkout",
+		"skipping alias.*gap.*alias expansion skips \\$2",
+		"skipping alias.*badshell.*unrecognized alias shell \"zsh\"")
+
+	expected := `aliases:
+    co: pr checkout
+`
+	eq(t, mainBuf.String(), expected)
+}
+
+func TestAliasSet_placeholder_gap(t *testing.T) {
+	initBlankContext("", "OWNER/REPO", "trunk")
+	defer stubTerminal(true)()
+
+	mainBuf := bytes.Buffer{}
+	hostsBuf := bytes.Buffer{}
+	defer config.StubWriteConfig(&mainBuf, &hostsBuf)()
+
+	_, err := RunCommand(`alias set il 'issue list --author="$1" --label="$3"'`)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+
+	eq(t, err.Error(), `could not create alias: alias expansion skips $2: issue list --author="$1" --label="$3"`)
+}
+
+func TestAliasSet_placeholder_zero(t *testing.T) {
+	initBlankContext("", "OWNER/REPO", "trunk")
+	defer stubTerminal(true)()
+
+	mainBuf := bytes.Buffer{}
+	hostsBuf := bytes.Buffer{}
+	defer config.StubWriteConfig(&mainBuf, &hostsBuf)()
+
+	_, err := RunCommand(`alias set il 'issue list $0'`)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+
+	eq(t, err.Error(), `could not create alias: alias expansion references invalid placeholder $0: issue list $0`)
+}
+
+func TestExpandAlias_splat(t *testing.T) {
+	cfg := `---
+aliases:
+  prs: pr list $@
+`
+	initBlankContext(cfg, "OWNER/REPO", "trunk")
+
+	expanded, isShell, err := ExpandAlias([]string{"gh", "prs", "--label=bug", "--state=open"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	assert.False(t, isShell)
+	assert.Equal(t, []string{"pr", "list", "--label=bug", "--state=open"}, expanded)
+}
+
+func TestExpandAlias_named_arg(t *testing.T) {
+	cfg := `---
+aliases:
+  co: pr checkout ${repo}
+`
+	initBlankContext(cfg, "OWNER/REPO", "trunk")
+
+	expanded, _, err := ExpandAlias([]string{"gh", "co", "--arg", "repo=cli/cli"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	assert.Equal(t, []string{"pr", "checkout", "cli/cli"}, expanded)
+
+	_, _, err = ExpandAlias([]string{"gh", "co"})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	eq(t, err.Error(), "alias requires a value for ${repo}; pass --arg repo=VALUE")
+}
+
+func TestAliasSet_interactive_requires_terminal(t *testing.T) {
+	initBlankContext("", "OWNER/REPO", "trunk")
+
+	_, err := RunCommand("alias set --interactive")
+	if err == nil {
+		t.Fatal("expected error")
+	}
+
+	eq(t, err.Error(), "interactive alias creation requires a terminal")
+}
+
+func TestExpandAlias_shell_prefix(t *testing.T) {
+	defer stubPwsh("pwsh")()
+	cfg := `---
+aliases:
+  ig: '!pwsh: gh issue list | Select-String cool'
+`
+	initBlankContext(cfg, "OWNER/REPO", "trunk")
+
+	expanded, isShell, err := ExpandAlias([]string{"gh", "ig"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	assert.True(t, isShell)
+	assert.Equal(t, []string{"pwsh", "-Command", "gh issue list | Select-String cool"}, expanded)
+}
+
+func TestExpandAlias_shell_bash(t *testing.T) {
+	defer stubBash("bash")()
+	cfg := `---
+aliases:
+  ig: '!bash: gh issue list | grep cool'
+`
+	initBlankContext(cfg, "OWNER/REPO", "trunk")
+
+	expanded, isShell, err := ExpandAlias([]string{"gh", "ig"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	assert.True(t, isShell)
+	assert.Equal(t, []string{"bash", "-c", "gh issue list | grep cool"}, expanded)
+}
+
+func TestExpandAlias_shell_cmd(t *testing.T) {
+	defer stubCmd("cmd.exe")()
+	cfg := `---
+aliases:
+  ig: '!cmd: gh issue list | findstr cool'
+`
+	initBlankContext(cfg, "OWNER/REPO", "trunk")
+
+	expanded, isShell, err := ExpandAlias([]string{"gh", "ig"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	assert.True(t, isShell)
+	assert.Equal(t, []string{"cmd.exe", "/C", "gh issue list | findstr cool"}, expanded)
+}
+
+func TestExpandAlias_shell_configured_default(t *testing.T) {
+	defer stubPwsh("pwsh")()
+	cfg := `---
+aliases_shell: pwsh
+aliases:
+  ig: '!gh issue list | Select-String cool'
+`
+	initBlankContext(cfg, "OWNER/REPO", "trunk")
+
+	expanded, isShell, err := ExpandAlias([]string{"gh", "ig"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	assert.True(t, isShell)
+	assert.Equal(t, []string{"pwsh", "-Command", "gh issue list | Select-String cool"}, expanded)
+}
+
+func TestAliasSet_unrecognized_shell(t *testing.T) {
+	initBlankContext("", "OWNER/REPO", "trunk")
+	defer stubTerminal(true)()
+
+	mainBuf := bytes.Buffer{}
+	hostsBuf := bytes.Buffer{}
+	defer config.StubWriteConfig(&mainBuf, &hostsBuf)()
+
+	_, err := RunCommand(`alias set ig '!zsh: gh issue list'`)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+
+	eq(t, err.Error(), `could not create alias: unrecognized alias shell "zsh"`)
+}